@@ -0,0 +1,388 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/tekkamanendless/compression-detector/detector"
+)
+
+func main() {
+	var debugValue bool
+	var stripLimit int
+	var exhaustiveValue bool
+	var maxDepthValue int
+	var workersValue int
+	var allValue bool
+	var outputValue string
+
+	var rootCommand = &cobra.Command{
+		Use:   "compression-detector",
+		Short: "Compression detector",
+		Long: `
+This tool attempts to determine the type of compression used in a file.
+`,
+		Args: cobra.MinimumNArgs(1),
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if debugValue {
+				logrus.SetLevel(logrus.DebugLevel)
+			}
+			switch outputValue {
+			case "text", "json", "ndjson":
+			default:
+				cmd.SilenceUsage = true
+				return fmt.Errorf("invalid --output value %q (must be one of: text, json, ndjson)", outputValue)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, filename := range args {
+				var reader io.Reader
+				if filename == "-" {
+					reader = os.Stdin
+				} else {
+					fileHandle, err := os.Open(filename)
+					if err != nil {
+						panic(err)
+					}
+					defer fileHandle.Close()
+					reader = fileHandle
+				}
+				contents, err := ioutil.ReadAll(reader)
+				if err != nil {
+					panic(err)
+				}
+				results := detectCompression(contents, stripLimit, exhaustiveValue, maxDepthValue, workersValue, allValue)
+				hash := sha256.Sum256(contents)
+				hashHex := hex.EncodeToString(hash[:])
+				for i := range results {
+					results[i].SHA256 = hashHex
+				}
+
+				if err := printResults(filename, results, outputValue); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	rootCommand.PersistentFlags().BoolVar(&debugValue, "debug", false, `Enable debug output`)
+	rootCommand.PersistentFlags().IntVar(&stripLimit, "strip-limit", 100, `Only strip off (at most) this many bytes from the front (use -1 for no limit)`)
+	rootCommand.PersistentFlags().BoolVar(&exhaustiveValue, "exhaustive", false, `Ignore magic-number signatures and try every decompressor at every offset`)
+	rootCommand.PersistentFlags().IntVar(&maxDepthValue, "max-depth", 5, `Maximum number of nested layers (e.g. gzip -> tar) to probe after a successful decompression`)
+	rootCommand.PersistentFlags().IntVar(&workersValue, "workers", runtime.NumCPU(), `Number of (offset, codec) trials to run concurrently during the exhaustive search`)
+	rootCommand.PersistentFlags().BoolVar(&allValue, "all", false, `Keep running the exhaustive search to collect every successful codec, instead of stopping at the first hit`)
+	rootCommand.PersistentFlags().StringVar(&outputValue, "output", "text", `Output format: text, json, or ndjson`)
+
+	if err := rootCommand.Execute(); err != nil {
+		// Cobra has already printed err to stderr; a mistyped flag
+		// shouldn't also look like an internal crash with a stack trace.
+		os.Exit(1)
+	}
+}
+
+// DecompressionResult describes one codec's outcome when tried against a
+// byte slice, successful or not.
+type DecompressionResult struct {
+	Name               string
+	StartByte          int
+	CompressedSize     int
+	DecompressedSize   int
+	MagicOffset        int
+	DecompressionError string
+	Layers             []string
+	LayerSizes         []int
+	SHA256             string
+	Backend            string
+}
+
+// fileResult is the "json" output shape: every detection found for a
+// single file.
+type fileResult struct {
+	Filename string
+	Results  []DecompressionResult
+}
+
+// ndjsonRecord is the "ndjson" output shape: a single detection, tagged
+// with the file it came from.
+type ndjsonRecord struct {
+	Filename string
+	DecompressionResult
+}
+
+// printResults writes results for filename to stdout in the requested
+// format.
+func printResults(filename string, results []DecompressionResult, output string) error {
+	switch output {
+	case "json":
+		encoded, err := json.Marshal(fileResult{Filename: filename, Results: results})
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(encoded))
+	case "ndjson":
+		for _, result := range results {
+			encoded, err := json.Marshal(ndjsonRecord{Filename: filename, DecompressionResult: result})
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(encoded))
+		}
+	default:
+		spew.Dump(results)
+	}
+	return nil
+}
+
+// magicSignatureMatch is a Codec found at a particular offset in a buffer
+// via its Match method.
+type magicSignatureMatch struct {
+	Codec  detector.Codec
+	Offset int
+}
+
+// findMagicSignatures scans contents[:stripLimit] for any codec whose
+// Match succeeds, returning every match it finds, in ascending offset
+// order. stripLimit is clamped to len(contents) if it's larger, so
+// callers don't need to pre-clamp it themselves.
+func findMagicSignatures(codecs []detector.Codec, contents []byte, stripLimit int) []magicSignatureMatch {
+	matches := []magicSignatureMatch{}
+
+	if stripLimit > len(contents) {
+		stripLimit = len(contents)
+	}
+
+	for startByte := 0; startByte < stripLimit; startByte++ {
+		for _, codec := range codecs {
+			if codec.Match(contents[startByte:]) {
+				matches = append(matches, magicSignatureMatch{Codec: codec, Offset: startByte})
+			}
+		}
+	}
+
+	return matches
+}
+
+// ctxReader aborts pending Read calls as soon as ctx is done, so a
+// Codec.Decompress blocked on reading from it notices cancellation.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c ctxReader) Read(p []byte) (int, error) {
+	select {
+	case <-c.ctx.Done():
+		return 0, c.ctx.Err()
+	default:
+	}
+	return c.r.Read(p)
+}
+
+// Context returns the reader's context, so codecs that shell out to an
+// external process (see detector.externalCodec) can bind its lifetime to
+// the same cancellation.
+func (c ctxReader) Context() context.Context { return c.ctx }
+
+// decompress runs codec against theseContents, enforcing the invariant
+// that the codec must consume the entire buffer. ctx is threaded through
+// to the underlying reader so a cancellation aborts the read promptly.
+func decompress(ctx context.Context, codec detector.Codec, theseContents []byte) ([]byte, error) {
+	buffer := bytes.NewReader(theseContents)
+	reader, err := codec.Decompress(ctxReader{ctx: ctx, r: buffer})
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	result, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if buffer.Len() > 0 {
+		return nil, fmt.Errorf("Buffer still has %d bytes (only read %d)", buffer.Len(), len(theseContents)-buffer.Len())
+	}
+	return result, nil
+}
+
+// isTar reports whether data can be read as a tar archive.
+func isTar(data []byte) bool {
+	_, err := tar.NewReader(bytes.NewReader(data)).Next()
+	return err == nil
+}
+
+// detectLayers follows a chain of nested formats starting with
+// (topName, topSize, payload): it checks whether payload is itself a tar
+// archive, and otherwise whether d's DetectStream finds a codec matching
+// its header, decompressing and repeating until neither holds or
+// maxDepth layers have been probed. This mirrors the top-level
+// magic-number fast path, but only ever looks at offset 0, since a
+// decompressed payload's next layer (if any) starts immediately.
+func detectLayers(d *detector.Detector, topName string, topSize int, payload []byte, maxDepth int) ([]string, []int) {
+	layers := []string{topName}
+	sizes := []int{topSize}
+
+	current := payload
+	for depth := 1; depth < maxDepth; depth++ {
+		if isTar(current) {
+			layers = append(layers, "tar")
+			sizes = append(sizes, len(current))
+			break
+		}
+
+		nextCodec, _, err := d.DetectStream(bytes.NewReader(current))
+		if err != nil {
+			break
+		}
+
+		nextBytes, err := decompress(context.Background(), nextCodec, current)
+		if err != nil {
+			break
+		}
+
+		layers = append(layers, nextCodec.Name())
+		sizes = append(sizes, len(nextBytes))
+		current = nextBytes
+	}
+
+	return layers, sizes
+}
+
+// trial is one (offset, codec) pair to attempt during the exhaustive
+// search.
+type trial struct {
+	offset int
+	codec  detector.Codec
+}
+
+// runExhaustiveSearch tries every (offset, codec) pair up to stripLimit
+// concurrently, using up to workers goroutines at a time. By default, it
+// cancels the remaining trials as soon as the lowest successful offset is
+// known and returns only the codecs that succeeded at that offset,
+// mirroring the serial search's "stop at the first offset with results"
+// behavior. If all is true, it instead lets every trial run to
+// completion and returns every successful codec found at every offset.
+func runExhaustiveSearch(contents []byte, d *detector.Detector, stripLimit int, workers int, all bool, maxDepth int) []DecompressionResult {
+	if workers < 1 {
+		workers = 1
+	}
+	if stripLimit > len(contents) {
+		stripLimit = len(contents)
+	}
+
+	trials := make([]trial, 0, stripLimit*len(d.Codecs))
+	for offset := 0; offset < stripLimit; offset++ {
+		for _, codec := range d.Codecs {
+			trials = append(trials, trial{offset: offset, codec: codec})
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(workers)
+
+	var mu sync.Mutex
+	results := []DecompressionResult{}
+	lowestOffset := -1
+
+	for _, t := range trials {
+		t := t
+		group.Go(func() error {
+			if groupCtx.Err() != nil {
+				return nil
+			}
+
+			theseContents := contents[t.offset:]
+			decompressedBytes, err := decompress(groupCtx, t.codec, theseContents)
+			if err != nil || len(decompressedBytes) == 0 {
+				logrus.Debugf("Could not decompress from byte %d with %s: %v", t.offset, t.codec.Name(), err)
+				return nil
+			}
+			logrus.Infof("Successfully decompressed from byte %d with %s: %d -> %d", t.offset, t.codec.Name(), len(theseContents), len(decompressedBytes))
+
+			result := DecompressionResult{
+				Name:             t.codec.Name(),
+				StartByte:        t.offset,
+				CompressedSize:   len(theseContents),
+				DecompressedSize: len(decompressedBytes),
+				Backend:          t.codec.Backend(),
+			}
+			result.Layers, result.LayerSizes = detectLayers(d, t.codec.Name(), len(theseContents), decompressedBytes, maxDepth)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !all {
+				if lowestOffset >= 0 && t.offset > lowestOffset {
+					return nil
+				}
+				if lowestOffset < 0 || t.offset < lowestOffset {
+					lowestOffset = t.offset
+					results = results[:0]
+				}
+				cancel()
+			}
+			results = append(results, result)
+			return nil
+		})
+	}
+
+	group.Wait()
+
+	return results
+}
+
+func detectCompression(contents []byte, stripLimit int, exhaustive bool, maxDepth int, workers int, all bool) []DecompressionResult {
+	results := []DecompressionResult{}
+	d := detector.NewDetector()
+
+	if stripLimit < 0 || stripLimit > len(contents) {
+		stripLimit = len(contents)
+	}
+
+	if !exhaustive {
+		matches := findMagicSignatures(d.Codecs, contents, stripLimit)
+		if len(matches) > 0 {
+			for _, match := range matches {
+				theseContents := contents[match.Offset:]
+				decompressedBytes, err := decompress(context.Background(), match.Codec, theseContents)
+				result := DecompressionResult{
+					Name:           match.Codec.Name(),
+					StartByte:      match.Offset,
+					CompressedSize: len(theseContents),
+					MagicOffset:    match.Offset,
+					Backend:        match.Codec.Backend(),
+				}
+				if err != nil {
+					logrus.Debugf("Magic signature %s matched at byte %d, but decompression failed: %v", match.Codec.Name(), match.Offset, err)
+					result.DecompressionError = err.Error()
+				} else {
+					logrus.Infof("Successfully decompressed from byte %d with %s: %d -> %d", match.Offset, match.Codec.Name(), len(theseContents), len(decompressedBytes))
+					result.DecompressedSize = len(decompressedBytes)
+					result.Layers, result.LayerSizes = detectLayers(d, match.Codec.Name(), len(theseContents), decompressedBytes, maxDepth)
+				}
+				results = append(results, result)
+			}
+			return results
+		}
+		logrus.Debugf("No magic signatures found; falling back to the exhaustive search")
+	}
+
+	return runExhaustiveSearch(contents, d, stripLimit, workers, all, maxDepth)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/tekkamanendless/compression-detector/detector"
+)
+
+// gzipBytes gzip-compresses data, for building nested-layer test fixtures.
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write(data); err != nil {
+		t.Fatalf("gzip.Write: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("gzip.Close: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestFindMagicSignatures(t *testing.T) {
+	codecs := detector.DefaultCodecs()
+	gzipMagic := []byte{0x1f, 0x8b, 0x08, 0x00}
+
+	tests := []struct {
+		name        string
+		contents    []byte
+		stripLimit  int
+		wantOffsets []int
+	}{
+		{
+			name:        "magic number at the very start",
+			contents:    gzipMagic,
+			stripLimit:  100,
+			wantOffsets: []int{0},
+		},
+		{
+			name:        "magic number after leading padding",
+			contents:    append([]byte{0x00, 0x00, 0x00, 0x00, 0x00}, gzipMagic...),
+			stripLimit:  100,
+			wantOffsets: []int{5},
+		},
+		{
+			name:        "stripLimit cuts off before the magic number",
+			contents:    append([]byte{0x00, 0x00, 0x00, 0x00, 0x00}, gzipMagic...),
+			stripLimit:  5,
+			wantOffsets: nil,
+		},
+		{
+			name:        "no magic number anywhere",
+			contents:    []byte{0x01, 0x02, 0x03, 0x04},
+			stripLimit:  100,
+			wantOffsets: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matches := findMagicSignatures(codecs, test.contents, test.stripLimit)
+
+			var offsets []int
+			for _, match := range matches {
+				if match.Codec.Name() == "gzip" {
+					offsets = append(offsets, match.Offset)
+				}
+			}
+
+			if len(offsets) != len(test.wantOffsets) {
+				t.Fatalf("got gzip offsets %v, want %v", offsets, test.wantOffsets)
+			}
+			for i := range offsets {
+				if offsets[i] != test.wantOffsets[i] {
+					t.Fatalf("got gzip offsets %v, want %v", offsets, test.wantOffsets)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectLayers(t *testing.T) {
+	d := detector.NewDetector()
+	plain := []byte("the innermost payload")
+
+	t.Run("unwraps nested layers until no codec matches", func(t *testing.T) {
+		innerGzip := gzipBytes(t, plain)
+		outerGzip := gzipBytes(t, innerGzip)
+
+		layers, sizes := detectLayers(d, "gzip", len(outerGzip), innerGzip, 5)
+
+		wantLayers := []string{"gzip", "gzip"}
+		if len(layers) != len(wantLayers) {
+			t.Fatalf("got layers %v, want %v", layers, wantLayers)
+		}
+		for i := range wantLayers {
+			if layers[i] != wantLayers[i] {
+				t.Fatalf("got layers %v, want %v", layers, wantLayers)
+			}
+		}
+		if len(sizes) != len(layers) {
+			t.Fatalf("got %d sizes for %d layers", len(sizes), len(layers))
+		}
+	})
+
+	t.Run("stops at maxDepth even though another layer would match", func(t *testing.T) {
+		innerGzip := gzipBytes(t, plain)
+		middleGzip := gzipBytes(t, innerGzip)
+		outerGzip := gzipBytes(t, middleGzip)
+
+		layers, _ := detectLayers(d, "gzip", len(outerGzip), middleGzip, 2)
+
+		wantLayers := []string{"gzip", "gzip"}
+		if len(layers) != len(wantLayers) {
+			t.Fatalf("got layers %v, want %v (maxDepth should have stopped unwrapping)", layers, wantLayers)
+		}
+	})
+
+	t.Run("a tar archive ends the chain without trying further codecs", func(t *testing.T) {
+		var tarBuffer bytes.Buffer
+		tarWriter := tar.NewWriter(&tarBuffer)
+		if err := tarWriter.WriteHeader(&tar.Header{Name: "a.txt", Size: int64(len(plain)), Mode: 0644}); err != nil {
+			t.Fatalf("tar.WriteHeader: %v", err)
+		}
+		if _, err := tarWriter.Write(plain); err != nil {
+			t.Fatalf("tar.Write: %v", err)
+		}
+		if err := tarWriter.Close(); err != nil {
+			t.Fatalf("tar.Close: %v", err)
+		}
+		tarBytes := tarBuffer.Bytes()
+		outerGzip := gzipBytes(t, tarBytes)
+
+		layers, _ := detectLayers(d, "gzip", len(outerGzip), tarBytes, 5)
+
+		wantLayers := []string{"gzip", "tar"}
+		if len(layers) != len(wantLayers) || layers[1] != "tar" {
+			t.Fatalf("got layers %v, want %v", layers, wantLayers)
+		}
+	})
+}
+
+func hasGzipResultAt(results []DecompressionResult, offset int) bool {
+	for _, result := range results {
+		if result.Name == "gzip" && result.StartByte == offset {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRunExhaustiveSearch(t *testing.T) {
+	d := detector.NewDetector()
+
+	// Two independently-valid gzip streams back to back, so the
+	// exhaustive search finds a genuine match at offset 0 (the whole
+	// buffer, since gzip.Reader reads concatenated members) and another
+	// at the offset where the second stream starts.
+	first := gzipBytes(t, []byte("first payload"))
+	second := gzipBytes(t, []byte("second payload"))
+	contents := append(append([]byte{}, first...), second...)
+	secondOffset := len(first)
+
+	t.Run("default stops at the lowest successful offset", func(t *testing.T) {
+		results := runExhaustiveSearch(contents, d, len(contents), 4, false, 5)
+
+		if !hasGzipResultAt(results, 0) {
+			t.Fatalf("expected a gzip match at offset 0, got %+v", results)
+		}
+		if hasGzipResultAt(results, secondOffset) {
+			t.Fatalf("expected early-cancel to drop the offset-%d match, got %+v", secondOffset, results)
+		}
+		for _, result := range results {
+			if result.StartByte != 0 {
+				t.Fatalf("expected every result to be at offset 0, got %+v", results)
+			}
+		}
+	})
+
+	t.Run("--all collects matches at every offset", func(t *testing.T) {
+		results := runExhaustiveSearch(contents, d, len(contents), 4, true, 5)
+
+		if !hasGzipResultAt(results, 0) {
+			t.Fatalf("expected a gzip match at offset 0, got %+v", results)
+		}
+		if !hasGzipResultAt(results, secondOffset) {
+			t.Fatalf("expected --all to keep the offset-%d match too, got %+v", secondOffset, results)
+		}
+	})
+}
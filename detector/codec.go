@@ -0,0 +1,25 @@
+package detector
+
+import "io"
+
+// Codec represents a single compression format that a Detector knows how
+// to recognize and decompress.
+type Codec interface {
+	// Name returns the codec's short, canonical name (e.g. "gzip").
+	Name() string
+
+	// Match reports whether head, the first few bytes of a stream, looks
+	// like the start of this codec's format. Codecs with no reliable
+	// magic number of their own always return false here, so they are
+	// only found by an exhaustive search rather than DetectStream.
+	Match(head []byte) bool
+
+	// Decompress wraps r, returning a reader that yields the
+	// decompressed payload.
+	Decompress(r io.Reader) (io.ReadCloser, error)
+
+	// Backend identifies what actually performs the decompression, e.g.
+	// "go" for this package's own implementation, or an external binary
+	// name such as "pigz" when one was used instead.
+	Backend() string
+}
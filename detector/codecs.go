@@ -0,0 +1,226 @@
+package detector
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/lzw"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4"
+	"github.com/rasky/go-lzo"
+	"github.com/ulikunitz/xz"
+)
+
+// DefaultCodecs returns every codec this package knows about, in the same
+// order they're tried when no magic number matches. Each name appears at
+// most once: where an external backend (see externalCodecs) is available
+// for a codec, it replaces that codec's pure-Go entry in place instead of
+// being added alongside it, so a file is never reported twice under the
+// same name just because an accelerator happens to be on $PATH.
+func DefaultCodecs() []Codec {
+	codecs := []Codec{
+		bzip2Codec{},
+		gzipCodec{},
+		lzoCodec{},
+		lz4Codec{},
+	}
+	for _, width := range []int{2, 3, 4, 5, 6, 7, 8} {
+		codecs = append(codecs, lzwCodec{order: lzw.LSB, width: width})
+	}
+	for _, width := range []int{2, 3, 4, 5, 6, 7, 8} {
+		codecs = append(codecs, lzwCodec{order: lzw.MSB, width: width})
+	}
+	codecs = append(codecs,
+		snappyBlockCodec{},
+		snappyStreamCodec{},
+		zlibCodec{},
+		zstdCodec{},
+		xzCodec{},
+	)
+
+	for _, external := range externalCodecs() {
+		for i, codec := range codecs {
+			if codec.Name() == external.Name() {
+				codecs[i] = external
+				break
+			}
+		}
+	}
+
+	return codecs
+}
+
+// goBackend is embedded in every codec implemented with this package's
+// own pure-Go decompressors, so they all report Backend() "go" without
+// repeating the method.
+type goBackend struct{}
+
+func (goBackend) Backend() string { return "go" }
+
+type bzip2Codec struct{ goBackend }
+
+func (bzip2Codec) Name() string { return "bzip2" }
+
+func (bzip2Codec) Match(head []byte) bool {
+	return bytes.HasPrefix(head, []byte("BZh"))
+}
+
+func (bzip2Codec) Decompress(r io.Reader) (io.ReadCloser, error) {
+	return ioutil.NopCloser(bzip2.NewReader(r)), nil
+}
+
+type gzipCodec struct{ goBackend }
+
+func (gzipCodec) Name() string { return "gzip" }
+
+func (gzipCodec) Match(head []byte) bool {
+	return bytes.HasPrefix(head, []byte{0x1f, 0x8b})
+}
+
+func (gzipCodec) Decompress(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+type lzoCodec struct{ goBackend }
+
+func (lzoCodec) Name() string { return "lzo" }
+
+// lzo has no magic number of its own, so it's only tried in exhaustive mode.
+func (lzoCodec) Match(head []byte) bool { return false }
+
+func (lzoCodec) Decompress(r io.Reader) (io.ReadCloser, error) {
+	result, err := lzo.Decompress1X(r, 0 /*inLen*/, 0 /*outLen*/)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(result)), nil
+}
+
+type lz4Codec struct{ goBackend }
+
+func (lz4Codec) Name() string { return "lz4" }
+
+func (lz4Codec) Match(head []byte) bool {
+	return bytes.HasPrefix(head, []byte{0x04, 0x22, 0x4d, 0x18})
+}
+
+func (lz4Codec) Decompress(r io.Reader) (io.ReadCloser, error) {
+	return ioutil.NopCloser(lz4.NewReader(r)), nil
+}
+
+type lzwCodec struct {
+	goBackend
+	order lzw.Order
+	width int
+}
+
+func (c lzwCodec) Name() string {
+	orderName := "lsb"
+	if c.order == lzw.MSB {
+		orderName = "msb"
+	}
+	return fmt.Sprintf("lzw-%s-%d", orderName, c.width)
+}
+
+// Raw LZW streams have no magic number of their own, so they're only
+// tried in exhaustive mode.
+func (c lzwCodec) Match(head []byte) bool { return false }
+
+func (c lzwCodec) Decompress(r io.Reader) (io.ReadCloser, error) {
+	return lzw.NewReader(r, c.order, c.width), nil
+}
+
+type snappyBlockCodec struct{ goBackend }
+
+func (snappyBlockCodec) Name() string { return "snappy-block" }
+
+// Raw snappy blocks have no magic number of their own, so they're only
+// tried in exhaustive mode.
+func (snappyBlockCodec) Match(head []byte) bool { return false }
+
+func (snappyBlockCodec) Decompress(r io.Reader) (io.ReadCloser, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	result, err := snappy.Decode(nil, data)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(result)), nil
+}
+
+type snappyStreamCodec struct{ goBackend }
+
+func (snappyStreamCodec) Name() string { return "snappy-stream" }
+
+func (snappyStreamCodec) Match(head []byte) bool {
+	return bytes.HasPrefix(head, []byte{0xff, 0x06, 0x00, 0x00, 0x73, 0x4e, 0x61, 0x50, 0x70, 0x59})
+}
+
+func (snappyStreamCodec) Decompress(r io.Reader) (io.ReadCloser, error) {
+	return ioutil.NopCloser(snappy.NewReader(r)), nil
+}
+
+type zlibCodec struct{ goBackend }
+
+func (zlibCodec) Name() string { return "zlib" }
+
+func (zlibCodec) Match(head []byte) bool {
+	return bytes.HasPrefix(head, []byte{0x78, 0x01}) ||
+		bytes.HasPrefix(head, []byte{0x78, 0x9c}) ||
+		bytes.HasPrefix(head, []byte{0x78, 0xda})
+}
+
+func (zlibCodec) Decompress(r io.Reader) (io.ReadCloser, error) {
+	return zlib.NewReader(r)
+}
+
+type zstdCodec struct{ goBackend }
+
+func (zstdCodec) Name() string { return "zstd" }
+
+func (zstdCodec) Match(head []byte) bool {
+	return bytes.HasPrefix(head, []byte{0x28, 0xb5, 0x2f, 0xfd})
+}
+
+func (zstdCodec) Decompress(r io.Reader) (io.ReadCloser, error) {
+	decoder, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return zstdReadCloser{decoder}, nil
+}
+
+// zstdReadCloser adapts *zstd.Decoder, whose Close method takes no error,
+// to io.ReadCloser.
+type zstdReadCloser struct {
+	*zstd.Decoder
+}
+
+func (z zstdReadCloser) Close() error {
+	z.Decoder.Close()
+	return nil
+}
+
+type xzCodec struct{ goBackend }
+
+func (xzCodec) Name() string { return "xz" }
+
+func (xzCodec) Match(head []byte) bool {
+	return bytes.HasPrefix(head, []byte{0xfd, 0x37, 0x7a, 0x58, 0x5a, 0x00})
+}
+
+func (xzCodec) Decompress(r io.Reader) (io.ReadCloser, error) {
+	reader, err := xz.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(reader), nil
+}
@@ -0,0 +1,51 @@
+// Package detector identifies the compression codec used by a stream or
+// byte slice and exposes decompressors for each codec it recognizes.
+package detector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// maxMagicLength is the number of header bytes DetectStream peeks at to
+// match against registered Codecs.
+const maxMagicLength = 16
+
+// Detector holds the set of Codecs to try when identifying a stream.
+type Detector struct {
+	Codecs []Codec
+}
+
+// NewDetector returns a Detector configured with every codec this package
+// knows about.
+func NewDetector() *Detector {
+	return &Detector{Codecs: DefaultCodecs()}
+}
+
+// DetectStream peeks at the start of r and returns the first registered
+// Codec whose Match succeeds, along with a reader positioned at the very
+// start of the stream (the peeked bytes are not consumed). Pass the
+// returned reader to the Codec's Decompress method to read the payload.
+func (d *Detector) DetectStream(r io.Reader) (Codec, io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	head, _ := buffered.Peek(maxMagicLength)
+
+	for _, codec := range d.Codecs {
+		if codec.Match(head) {
+			return codec, buffered, nil
+		}
+	}
+
+	return nil, buffered, fmt.Errorf("no codec matched the stream header")
+}
+
+// CodecByName returns the registered Codec with the given name, if any.
+func (d *Detector) CodecByName(name string) (Codec, bool) {
+	for _, codec := range d.Codecs {
+		if codec.Name() == name {
+			return codec, true
+		}
+	}
+	return nil, false
+}
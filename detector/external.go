@@ -0,0 +1,94 @@
+package detector
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// disableExternalEnvVar disables registration of external decompressor
+// backends, for environments that want to stick to pure-Go codecs.
+const disableExternalEnvVar = "COMPRESSION_DETECTOR_DISABLE_EXTERNAL"
+
+// externalCandidate is one external-tool backend that can stand in for
+// an existing pure-Go Codec.
+type externalCandidate struct {
+	binary string
+	args   []string
+	base   Codec
+}
+
+// externalCandidates lists the external tools this package knows how to
+// use, each paired with the pure-Go Codec it accelerates.
+var externalCandidates = []externalCandidate{
+	{binary: "pigz", args: []string{"-d", "-c"}, base: gzipCodec{}},
+	{binary: "pbzip2", args: []string{"-d", "-c"}, base: bzip2Codec{}},
+	{binary: "unxz", args: []string{"-d", "-c"}, base: xzCodec{}},
+	{binary: "zstd", args: []string{"-d", "-c"}, base: zstdCodec{}},
+}
+
+// externalCodecs returns a Codec for every externalCandidate whose binary
+// is on $PATH, unless disabled via disableExternalEnvVar. DefaultCodecs
+// substitutes each of these in place of the pure-Go codec it accelerates,
+// so a file is matched (and reported) exactly once either way, and
+// DefaultCodecs keeps working unchanged when no external tools are
+// available.
+func externalCodecs() []Codec {
+	if os.Getenv(disableExternalEnvVar) == "1" {
+		return nil
+	}
+
+	var codecs []Codec
+	for _, candidate := range externalCandidates {
+		if _, err := exec.LookPath(candidate.binary); err != nil {
+			continue
+		}
+		codecs = append(codecs, externalCodec{candidate: candidate})
+	}
+	return codecs
+}
+
+// contextProvider is implemented by readers that carry a context.Context
+// along with them, such as the one the cmd/compression-detector CLI
+// wraps trial reads in. externalCodec uses it, if present, to bind the
+// external process's lifetime to the caller's cancellation.
+type contextProvider interface {
+	Context() context.Context
+}
+
+// externalCodec decompresses by shelling out to an external tool instead
+// of using candidate.base's pure-Go implementation. It defers to base for
+// Name and Match, so it's interchangeable with it everywhere except
+// Decompress and Backend.
+type externalCodec struct {
+	candidate externalCandidate
+}
+
+func (e externalCodec) Name() string { return e.candidate.base.Name() }
+
+func (e externalCodec) Match(head []byte) bool { return e.candidate.base.Match(head) }
+
+func (e externalCodec) Backend() string { return e.candidate.binary }
+
+func (e externalCodec) Decompress(r io.Reader) (io.ReadCloser, error) {
+	ctx := context.Background()
+	if provider, ok := r.(contextProvider); ok {
+		ctx = provider.Context()
+	}
+
+	cmd := exec.CommandContext(ctx, e.candidate.binary, e.candidate.args...)
+	cmd.Stdin = r
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w (%s)", e.candidate.binary, err, strings.TrimSpace(stderr.String()))
+	}
+	return ioutil.NopCloser(bytes.NewReader(output)), nil
+}